@@ -0,0 +1,398 @@
+package ghostferry
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/go-mysql-org/go-mysql/replication"
+	"github.com/sirupsen/logrus"
+)
+
+// BinlogPosition is a (file, offset) coordinate in the source's binary log,
+// used when the Config.PositionMode is PositionModeFilePos.
+type BinlogPosition struct {
+	File     string
+	Position uint32
+}
+
+// BinlogStreamer reads the source's binary log and dispatches DML events to
+// its registered listeners. Depending on Config.PositionMode it tracks its
+// progress either as a BinlogPosition or as a GTID set.
+type BinlogStreamer struct {
+	Db           *sql.DB
+	Config       *Config
+	ErrorHandler *ErrorHandler
+	Throttler    *Throttler
+
+	// Ctx is cancelled by Ferry.Run to signal every subsystem to shut down
+	// uniformly, in addition to the signals passed to Run.
+	Ctx context.Context
+
+	// StateTracker, when set, is updated with the current resumable
+	// position after every transaction so that a crashed ferry can be
+	// restarted from the last committed position.
+	StateTracker *StateTracker
+
+	// Tables is consulted to resolve a RowsEvent's table ID to a
+	// TableSchema and to filter out rows for tables we're not copying. Set
+	// by Ferry.Start once loadTables has run.
+	Tables TableSchemaCache
+
+	serverID        uint32
+	semiSyncEnabled bool
+	eventListeners  []func([]DMLEvent) error
+
+	lastStreamedBinlogPosition BinlogPosition
+	lastStreamedGTIDSet        mysql.GTIDSet
+
+	// skipTransaction is set while streaming a transaction whose GTID was
+	// already in lastStreamedGTIDSet, so its rows are not dispatched again.
+	skipTransaction bool
+
+	stopRequested bool
+	stopped       chan struct{}
+	mutex         sync.Mutex
+
+	logger *logrus.Entry
+}
+
+func (s *BinlogStreamer) Initialize() error {
+	s.logger = logrus.WithField("tag", "binlog_streamer")
+	s.stopped = make(chan struct{})
+
+	if s.Ctx == nil {
+		s.Ctx = context.Background()
+	}
+
+	s.serverID = s.Config.ServerID
+	if s.serverID == 0 {
+		s.serverID = 1
+	}
+
+	return nil
+}
+
+func (s *BinlogStreamer) AddEventListener(listener func([]DMLEvent) error) {
+	s.eventListeners = append(s.eventListeners, listener)
+}
+
+// ConnectBinlogStreamerToMysql determines the starting position for the
+// binlog stream. In PositionModeGTID, this is the source's currently
+// executed GTID set, fetched from @@GLOBAL.gtid_executed, so that replaying
+// already-applied transactions can be filtered out as events flow in. In
+// PositionModeFilePos, this is the classic SHOW MASTER STATUS coordinate.
+func (s *BinlogStreamer) ConnectBinlogStreamerToMysql() error {
+	if s.Config.SemiSyncEnabled {
+		supported, err := sourceSupportsSemiSync(s.Db)
+		if err != nil {
+			return fmt.Errorf("failed to check rpl_semi_sync_master_enabled: %v", err)
+		}
+
+		if supported {
+			// The actual registration (SET @rpl_semi_sync_slave = 1 on the
+			// replication connection, and replying to the ACK magic byte on
+			// each event) is handled by go-mysql itself via
+			// BinlogSyncerConfig.SemiSyncEnabled in newBinlogSyncerConfig;
+			// s.Db is a database/sql pool and can't issue it on the right
+			// connection, so there's nothing to do here but flip the flag.
+			s.semiSyncEnabled = true
+			s.logger.Info("source supports semi-sync; will register as a semi-sync replica")
+		} else {
+			s.logger.Warn("SemiSyncEnabled is set but source does not have semi-sync enabled; continuing without it")
+		}
+	}
+
+	if s.Config.PositionMode == PositionModeGTID {
+		gtidSet, err := s.fetchExecutedGTIDSet()
+		if err != nil {
+			return fmt.Errorf("failed to fetch source gtid_executed: %v", err)
+		}
+
+		if s.StateTracker != nil {
+			if resumeSet := s.StateTracker.LastStoredGTIDSet(); resumeSet != nil {
+				gtidSet = resumeSet
+			}
+		}
+
+		s.lastStreamedGTIDSet = gtidSet
+		s.logger.WithField("gtid_set", gtidSet.String()).Info("starting binlog streaming from gtid set")
+		return nil
+	}
+
+	row := s.Db.QueryRow("SHOW MASTER STATUS")
+	var file string
+	var position uint32
+	var binlogDoDb, binlogIgnoreDb, executedGtidSet sql.NullString
+	if err := row.Scan(&file, &position, &binlogDoDb, &binlogIgnoreDb, &executedGtidSet); err != nil {
+		return fmt.Errorf("failed to SHOW MASTER STATUS: %v", err)
+	}
+
+	s.lastStreamedBinlogPosition = BinlogPosition{File: file, Position: position}
+	if s.StateTracker != nil {
+		if resumePos, ok := s.StateTracker.LastStoredBinlogPosition(); ok {
+			s.lastStreamedBinlogPosition = resumePos
+		}
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"file":     s.lastStreamedBinlogPosition.File,
+		"position": s.lastStreamedBinlogPosition.Position,
+	}).Info("starting binlog streaming from file position")
+
+	return nil
+}
+
+func (s *BinlogStreamer) fetchExecutedGTIDSet() (mysql.GTIDSet, error) {
+	row := s.Db.QueryRow("SELECT @@GLOBAL.gtid_executed")
+	var rawGTIDSet string
+	if err := row.Scan(&rawGTIDSet); err != nil {
+		return nil, err
+	}
+
+	return mysql.ParseGTIDSet(mysql.MySQLFlavor, rawGTIDSet)
+}
+
+// AdvanceGTIDSet folds a newly-seen transaction GTID into the streamer's
+// tracked set and, if the GTID was already present (i.e. this transaction
+// was already applied against the target in a previous run), reports that
+// the caller should skip replaying it.
+func (s *BinlogStreamer) AdvanceGTIDSet(event *replication.GTIDEvent) (alreadyApplied bool, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	gtid, err := gtidFromEvent(event)
+	if err != nil {
+		return false, err
+	}
+
+	if s.lastStreamedGTIDSet != nil && s.lastStreamedGTIDSet.Contain(gtid) {
+		return true, nil
+	}
+
+	if s.lastStreamedGTIDSet == nil {
+		s.lastStreamedGTIDSet = gtid
+	} else if err := s.lastStreamedGTIDSet.Update(gtid.String()); err != nil {
+		return false, fmt.Errorf("failed to fold gtid %s into tracked set: %v", gtid.String(), err)
+	}
+
+	if s.StateTracker != nil {
+		s.StateTracker.UpdateLastStoredGTIDSet(s.lastStreamedGTIDSet)
+	}
+
+	return false, nil
+}
+
+func gtidFromEvent(event *replication.GTIDEvent) (mysql.GTIDSet, error) {
+	gtid, err := event.GTIDNext()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gtid from event: %v", err)
+	}
+
+	return gtid, nil
+}
+
+// newBinlogSyncerConfig builds the go-mysql BinlogSyncerConfig used to open
+// the replication connection. SemiSyncEnabled is threaded straight through;
+// go-mysql takes care of replying to the semi-sync magic byte on each event
+// with the required ACK packet containing the last received log file/position.
+func (s *BinlogStreamer) newBinlogSyncerConfig() replication.BinlogSyncerConfig {
+	return replication.BinlogSyncerConfig{
+		ServerID:        s.serverID,
+		Flavor:          "mysql",
+		Host:            s.Config.SourceHost,
+		Port:            s.Config.SourcePort,
+		User:            s.Config.SourceUser,
+		Password:        s.Config.SourcePass,
+		SemiSyncEnabled: s.semiSyncEnabled,
+	}
+}
+
+// startSync opens the replication connection at the position determined by
+// ConnectBinlogStreamerToMysql: a GTID set in PositionModeGTID, or a
+// (file, offset) coordinate otherwise.
+func (s *BinlogStreamer) startSync(syncer *replication.BinlogSyncer) (*replication.BinlogStreamer, error) {
+	if s.Config.PositionMode == PositionModeGTID {
+		return syncer.StartSyncGTID(s.lastStreamedGTIDSet)
+	}
+
+	return syncer.StartSync(mysql.Position{
+		Name: s.lastStreamedBinlogPosition.File,
+		Pos:  s.lastStreamedBinlogPosition.Position,
+	})
+}
+
+// Run implements Runner. It streams the source's binlog, dispatching each
+// RowsEvent for a table we're copying to the registered event listeners and
+// advancing the tracked GTID set/file position as events flow in, until
+// either the context passed at construction is cancelled, a signal is
+// received, or FlushAndStop has been called (checked after each event, so a
+// flush request drains any event already in flight before stopping).
+func (s *BinlogStreamer) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	defer close(s.stopped)
+
+	syncer := replication.NewBinlogSyncer(s.newBinlogSyncerConfig())
+	defer syncer.Close()
+
+	streamer, err := s.startSync(syncer)
+	if err != nil {
+		close(ready)
+		return fmt.Errorf("failed to start binlog sync: %v", err)
+	}
+
+	close(ready)
+
+	ctx, cancel := context.WithCancel(s.Ctx)
+	defer cancel()
+
+	go func() {
+		select {
+		case <-signals:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for {
+		event, err := streamer.GetEvent(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to read binlog event: %v", err)
+		}
+
+		if err := s.handleEvent(event); err != nil {
+			return err
+		}
+
+		if s.shouldStop() {
+			return nil
+		}
+	}
+}
+
+// handleEvent advances the tracked position for a GTID/rotate/commit (XID)
+// event, or dispatches a RowsEvent for one of s.Tables to the registered
+// listeners. Rows of a transaction whose GTID was already applied are
+// skipped.
+func (s *BinlogStreamer) handleEvent(event *replication.BinlogEvent) error {
+	switch ev := event.Event.(type) {
+	case *replication.GTIDEvent:
+		if s.Config.PositionMode == PositionModeGTID {
+			alreadyApplied, err := s.AdvanceGTIDSet(ev)
+			if err != nil {
+				return fmt.Errorf("failed to advance gtid set: %v", err)
+			}
+			s.skipTransaction = alreadyApplied
+		}
+	case *replication.RotateEvent:
+		s.mutex.Lock()
+		s.lastStreamedBinlogPosition = BinlogPosition{File: string(ev.NextLogName), Position: uint32(ev.Position)}
+		s.mutex.Unlock()
+	case *replication.XIDEvent:
+		s.mutex.Lock()
+		s.lastStreamedBinlogPosition.Position = event.Header.LogPos
+		position := s.lastStreamedBinlogPosition
+		s.mutex.Unlock()
+		if s.StateTracker != nil && s.Config.PositionMode != PositionModeGTID {
+			s.StateTracker.UpdateLastStoredBinlogPosition(position)
+		}
+	case *replication.RowsEvent:
+		if s.skipTransaction {
+			return nil
+		}
+		return s.handleRowsEvent(event.Header.EventType, ev)
+	}
+
+	return nil
+}
+
+// handleRowsEvent converts a RowsEvent for a table in s.Tables into
+// BinlogDMLEvents and dispatches them to the registered listeners. Rows for
+// tables we're not copying are ignored. Each RowsEvent is dispatched as its
+// own batch rather than buffered until the enclosing transaction commits.
+func (s *BinlogStreamer) handleRowsEvent(eventType replication.EventType, ev *replication.RowsEvent) error {
+	table := s.Tables[string(ev.Table.Schema)+"."+string(ev.Table.Table)]
+	if table == nil {
+		return nil
+	}
+
+	s.mutex.Lock()
+	position := s.lastStreamedBinlogPosition
+	gtidSet := ""
+	if s.lastStreamedGTIDSet != nil {
+		gtidSet = s.lastStreamedGTIDSet.String()
+	}
+	s.mutex.Unlock()
+
+	var events []DMLEvent
+	switch eventType {
+	case replication.WRITE_ROWS_EVENTv0, replication.WRITE_ROWS_EVENTv1, replication.WRITE_ROWS_EVENTv2:
+		for _, row := range ev.Rows {
+			events = append(events, NewBinlogDMLEvent(table, nil, rowToValues(table, row), position, gtidSet))
+		}
+	case replication.DELETE_ROWS_EVENTv0, replication.DELETE_ROWS_EVENTv1, replication.DELETE_ROWS_EVENTv2:
+		for _, row := range ev.Rows {
+			events = append(events, NewBinlogDMLEvent(table, rowToValues(table, row), nil, position, gtidSet))
+		}
+	case replication.UPDATE_ROWS_EVENTv0, replication.UPDATE_ROWS_EVENTv1, replication.UPDATE_ROWS_EVENTv2:
+		for i := 0; i+1 < len(ev.Rows); i += 2 {
+			events = append(events, NewBinlogDMLEvent(table, rowToValues(table, ev.Rows[i]), rowToValues(table, ev.Rows[i+1]), position, gtidSet))
+		}
+	default:
+		return nil
+	}
+
+	for _, listener := range s.eventListeners {
+		if err := listener(events); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func rowToValues(table *TableSchema, row []interface{}) map[string]interface{} {
+	values := make(map[string]interface{}, len(table.Columns))
+	for i, col := range table.Columns {
+		if i < len(row) {
+			values[col] = row[i]
+		}
+	}
+	return values
+}
+
+func (s *BinlogStreamer) shouldStop() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.stopRequested
+}
+
+// FlushAndStop requests that the BinlogStreamer stop once it has caught up
+// to the source's current position.
+func (s *BinlogStreamer) FlushAndStop() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.stopRequested = true
+}
+
+// LastStreamedPosition returns the current resumable position as a string,
+// either a GTID set or a file:position coordinate, for display on the
+// ControlServer.
+func (s *BinlogStreamer) LastStreamedPosition() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.Config.PositionMode == PositionModeGTID {
+		if s.lastStreamedGTIDSet == nil {
+			return ""
+		}
+		return s.lastStreamedGTIDSet.String()
+	}
+
+	return fmt.Sprintf("%s:%d", s.lastStreamedBinlogPosition.File, s.lastStreamedBinlogPosition.Position)
+}
@@ -0,0 +1,118 @@
+package ghostferry
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"time"
+)
+
+// PositionMode controls how the BinlogStreamer tracks and resumes its
+// position in the source's binary log stream.
+type PositionMode string
+
+const (
+	// PositionModeFilePos tracks progress as a (binlog file, offset) pair.
+	// This is the default and matches Ghostferry's historical behaviour.
+	PositionModeFilePos PositionMode = "file_pos"
+
+	// PositionModeGTID tracks progress as a GTID set, allowing a run to be
+	// resumed against a different replica in the source's replication
+	// topology (e.g. after a failover).
+	PositionModeGTID PositionMode = "gtid"
+)
+
+type TLSConfig struct {
+	CertPath   string
+	ServerName string
+	SkipVerify bool
+}
+
+func (c *TLSConfig) RealTLSConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.SkipVerify,
+	}
+
+	if c.CertPath != "" {
+		rootCertPool := x509.NewCertPool()
+		pem, err := ioutil.ReadFile(c.CertPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cert file %s: %v", c.CertPath, err)
+		}
+
+		if ok := rootCertPool.AppendCertsFromPEM(pem); !ok {
+			return nil, fmt.Errorf("failed to append certs from %s", c.CertPath)
+		}
+
+		tlsConfig.RootCAs = rootCertPool
+	}
+
+	return tlsConfig, nil
+}
+
+type Config struct {
+	SourceHost string
+	SourcePort uint16
+	SourceUser string
+	SourcePass string
+	SourceTLS  *TLSConfig
+
+	TargetHost string
+	TargetPort uint16
+	TargetUser string
+	TargetPass string
+	TargetTLS  *TLSConfig
+
+	ApplicableDatabases map[string]bool
+	ApplicableTables    map[string]bool
+
+	ServerBindAddr string
+	WebBasedir     string
+
+	AutomaticCutover               bool
+	MaxWriteRetriesOnTargetDBError int
+
+	// PositionMode selects whether the BinlogStreamer resumes from a
+	// (file, offset) coordinate or a GTID set. Defaults to
+	// PositionModeFilePos when empty.
+	PositionMode PositionMode
+
+	// ServerID is the replication server id Ghostferry presents to the
+	// source when registering as a replica. It must be unique among all
+	// replicas connected to the source.
+	ServerID uint32
+
+	// SemiSyncEnabled, when true, registers Ghostferry as a semi-sync
+	// replica with the source so that the source waits for our ACK before
+	// considering a transaction durable. This reduces the chance of
+	// missing trailing events during cutover if the source crashes.
+	SemiSyncEnabled bool
+
+	// CheckpointInterval controls how often Ferry.Run persists its
+	// resumable state to the configured StateStore. Defaults to 10 seconds
+	// when unset and a StateStore is configured.
+	CheckpointInterval time.Duration
+
+	// LameDuckPeriod is how long the ControlServer keeps returning 503 from
+	// /status after a SIGTERM is received, before the BinlogStreamer is
+	// flushed and the rest of the subsystems are stopped. This gives
+	// external orchestrators (Kubernetes, BOSH) a chance to drain load
+	// balancers pointed at the ControlServer before the process exits.
+	LameDuckPeriod time.Duration
+}
+
+func (c *Config) Validate() error {
+	if c.PositionMode == "" {
+		c.PositionMode = PositionModeFilePos
+	}
+
+	switch c.PositionMode {
+	case PositionModeFilePos, PositionModeGTID:
+	default:
+		return fmt.Errorf("invalid PositionMode: %s", c.PositionMode)
+	}
+
+	return nil
+}
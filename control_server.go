@@ -0,0 +1,110 @@
+package ghostferry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// ControlServer exposes the running state of a Ferry over HTTP, used by
+// operators and orchestration tooling to observe progress and trigger
+// cutover.
+type ControlServer struct {
+	F       *Ferry
+	Addr    string
+	Basedir string
+
+	Ctx context.Context
+
+	server *http.Server
+
+	lameDucking bool
+	mutex       sync.Mutex
+}
+
+func (c *ControlServer) Initialize() error {
+	if c.Ctx == nil {
+		c.Ctx = context.Background()
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", c.handleStatus)
+
+	c.server = &http.Server{
+		Addr:    c.Addr,
+		Handler: mux,
+	}
+
+	return nil
+}
+
+type statusResponse struct {
+	OverallState   string `json:"OverallState"`
+	BinlogPosition string `json:"BinlogPosition,omitempty"`
+}
+
+func (c *ControlServer) handleStatus(w http.ResponseWriter, r *http.Request) {
+	c.mutex.Lock()
+	lameDucking := c.lameDucking
+	c.mutex.Unlock()
+
+	if lameDucking {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	resp := statusResponse{
+		OverallState: c.F.OverallState,
+	}
+
+	if c.F.BinlogStreamer != nil {
+		resp.BinlogPosition = c.F.BinlogStreamer.LastStreamedPosition()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// Run implements Runner. On receiving a signal, it enters a lame-duck
+// period during which /status reports 503 (so a load balancer can drain
+// traffic) before the HTTP server is actually shut down.
+func (c *ControlServer) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	serveErrCh := make(chan error, 1)
+	go func() {
+		serveErrCh <- c.server.ListenAndServe()
+	}()
+
+	close(ready)
+
+	select {
+	case err := <-serveErrCh:
+		if err == http.ErrServerClosed {
+			return nil
+		}
+		return err
+	case <-signals:
+	case <-c.Ctx.Done():
+	}
+
+	c.mutex.Lock()
+	c.lameDucking = true
+	c.mutex.Unlock()
+
+	if c.F.Config.LameDuckPeriod > 0 {
+		time.Sleep(c.F.Config.LameDuckPeriod)
+	}
+
+	if err := c.Shutdown(); err != nil {
+		return err
+	}
+
+	<-serveErrCh
+	return nil
+}
+
+func (c *ControlServer) Shutdown() error {
+	return c.server.Shutdown(context.Background())
+}
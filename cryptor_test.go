@@ -0,0 +1,75 @@
+package ghostferry
+
+import "testing"
+
+func testKey(b byte) []byte {
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = b
+	}
+	return key
+}
+
+func TestCryptorEncryptDecryptRoundTrip(t *testing.T) {
+	km := NewKeyManager("v1", map[string][]byte{"v1": testKey(1)})
+	c := NewCryptor(km)
+
+	plaintext := []byte(`{"BinlogPosition":{"File":"mysql-bin.000123","Position":456}}`)
+
+	ciphertext, err := c.Encrypt(plaintext)
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+
+	decrypted, err := c.Decrypt(ciphertext)
+	if err != nil {
+		t.Fatalf("Decrypt failed: %v", err)
+	}
+
+	if string(decrypted) != string(plaintext) {
+		t.Fatalf("round-trip mismatch: got %q, want %q", decrypted, plaintext)
+	}
+}
+
+func TestCryptorDecryptsUnderPreviousLabelAfterRotation(t *testing.T) {
+	km := NewKeyManager("v1", map[string][]byte{"v1": testKey(1)})
+	c := NewCryptor(km)
+
+	plaintext := []byte("some state")
+	ciphertext, err := c.Encrypt(plaintext)
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+
+	// Rotate: v2 becomes active, but v1's key is kept around for decrypting
+	// existing entries.
+	km.Keys["v2"] = testKey(2)
+	km.ActiveLabel = "v2"
+
+	decrypted, err := c.Decrypt(ciphertext)
+	if err != nil {
+		t.Fatalf("Decrypt of entry encrypted under the old label failed: %v", err)
+	}
+	if string(decrypted) != string(plaintext) {
+		t.Fatalf("round-trip mismatch: got %q, want %q", decrypted, plaintext)
+	}
+
+	label, err := c.Label(ciphertext)
+	if err != nil {
+		t.Fatalf("Label failed: %v", err)
+	}
+	if label != "v1" {
+		t.Fatalf("expected label v1, got %s", label)
+	}
+	if km.IsActive(label) {
+		t.Fatalf("v1 should no longer be the active label after rotation")
+	}
+}
+
+func TestKeyManagerUnknownLabel(t *testing.T) {
+	km := NewKeyManager("v1", map[string][]byte{"v1": testKey(1)})
+
+	if _, err := km.KeyForLabel("missing"); err == nil {
+		t.Fatal("expected an error for an unregistered label")
+	}
+}
@@ -0,0 +1,194 @@
+package ghostferry
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// dataIteratorBatchSize is the number of rows read per SELECT while
+// iterating a table's primary key range.
+const dataIteratorBatchSize = 200
+
+// errShutdownRequested unwinds iterateTable/Run without treating a signal
+// or context cancellation as a copy failure.
+var errShutdownRequested = errors.New("ghostferry: shutdown requested during row copy")
+
+// DataIterator walks the applicable tables' primary key ranges, reading
+// batches of rows from the source and dispatching them as synthetic DML
+// events to its registered listeners.
+type DataIterator struct {
+	Db           *sql.DB
+	Config       *Config
+	ErrorHandler *ErrorHandler
+	Throttler    *Throttler
+
+	Ctx context.Context
+
+	Tables []*TableSchema
+
+	eventListeners []func([]DMLEvent) error
+	doneListeners  []func() error
+
+	logger *logrus.Entry
+}
+
+func (d *DataIterator) Initialize() error {
+	d.logger = logrus.WithField("tag", "data_iterator")
+
+	if d.Ctx == nil {
+		d.Ctx = context.Background()
+	}
+
+	return nil
+}
+
+func (d *DataIterator) AddEventListener(listener func([]DMLEvent) error) {
+	d.eventListeners = append(d.eventListeners, listener)
+}
+
+func (d *DataIterator) AddDoneListener(listener func() error) {
+	d.doneListeners = append(d.doneListeners, listener)
+}
+
+// Run implements Runner. It iterates every table's primary key range in
+// order, emitting batches of rows to the registered event listeners between
+// checks of signals/d.Ctx.Done() so a shutdown request is honored promptly
+// instead of after the whole table finishes. Once every table has been
+// copied, it calls the done listeners and returns.
+func (d *DataIterator) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	close(ready)
+
+	for _, table := range d.Tables {
+		if err := d.iterateTable(table, signals); err != nil {
+			if err == errShutdownRequested {
+				return nil
+			}
+			return err
+		}
+	}
+
+	for _, listener := range d.doneListeners {
+		if err := listener(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// iterateTable copies table in batches of dataIteratorBatchSize rows,
+// ordered by its primary key, until there are no rows left past the last
+// cursor it read.
+func (d *DataIterator) iterateTable(table *TableSchema, signals <-chan os.Signal) error {
+	if len(table.PrimaryKey) == 0 {
+		return fmt.Errorf("table %s.%s has no primary key to iterate on", table.Schema, table.Name)
+	}
+
+	var lastPK interface{}
+	havePK := false
+
+	for {
+		select {
+		case <-signals:
+			return errShutdownRequested
+		case <-d.Ctx.Done():
+			return errShutdownRequested
+		default:
+		}
+
+		for d.Throttler != nil && d.Throttler.Throttled() {
+			select {
+			case <-signals:
+				return errShutdownRequested
+			case <-d.Ctx.Done():
+				return errShutdownRequested
+			case <-time.After(500 * time.Millisecond):
+			}
+		}
+
+		events, nextPK, more, err := d.copyBatch(table, lastPK, havePK)
+		if err != nil {
+			return fmt.Errorf("failed to copy batch of %s.%s: %v", table.Schema, table.Name, err)
+		}
+
+		if len(events) > 0 {
+			for _, listener := range d.eventListeners {
+				if err := listener(events); err != nil {
+					return err
+				}
+			}
+		}
+
+		if !more {
+			return nil
+		}
+
+		lastPK = nextPK
+		havePK = true
+	}
+}
+
+// copyBatch reads the next dataIteratorBatchSize rows of table whose
+// primary key sorts after lastPK (or the first batch, when !havePK), and
+// reports whether a further batch may still be pending.
+func (d *DataIterator) copyBatch(table *TableSchema, lastPK interface{}, havePK bool) (events []DMLEvent, newLastPK interface{}, more bool, err error) {
+	pkColumn := table.PrimaryKey[0]
+	columns := strings.Join(backtickedColumns(table.Columns), ", ")
+
+	query := fmt.Sprintf(
+		"SELECT %s FROM `%s`.`%s` ORDER BY `%s` LIMIT %d",
+		columns, table.Schema, table.Name, pkColumn, dataIteratorBatchSize,
+	)
+	args := []interface{}{}
+
+	if havePK {
+		query = fmt.Sprintf(
+			"SELECT %s FROM `%s`.`%s` WHERE `%s` > ? ORDER BY `%s` LIMIT %d",
+			columns, table.Schema, table.Name, pkColumn, pkColumn, dataIteratorBatchSize,
+		)
+		args = append(args, lastPK)
+	}
+
+	rows, err := d.Db.Query(query, args...)
+	if err != nil {
+		return nil, lastPK, false, err
+	}
+	defer rows.Close()
+
+	newLastPK = lastPK
+	count := 0
+
+	for rows.Next() {
+		scanValues := make([]interface{}, len(table.Columns))
+		scanArgs := make([]interface{}, len(table.Columns))
+		for i := range scanValues {
+			scanArgs[i] = &scanValues[i]
+		}
+
+		if err := rows.Scan(scanArgs...); err != nil {
+			return nil, lastPK, false, err
+		}
+
+		values := make(map[string]interface{}, len(table.Columns))
+		for i, col := range table.Columns {
+			values[col] = scanValues[i]
+		}
+
+		events = append(events, NewRowInsertEvent(table, values))
+		newLastPK = values[pkColumn]
+		count++
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, lastPK, false, err
+	}
+
+	return events, newLastPK, count == dataIteratorBatchSize, nil
+}
@@ -0,0 +1,181 @@
+package ghostferry
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DMLEvent is a single row-level change — whether sourced from the binlog
+// or synthesized by the DataIterator during the initial snapshot — that can
+// be applied to a MySQL target or serialized into a change record for a
+// sink like Kafka.
+type DMLEvent interface {
+	// AsSQLQuery renders this event as a SQL statement and its arguments,
+	// using tables to resolve column names and primary keys.
+	AsSQLQuery(tables TableSchemaCache) (string, []interface{}, error)
+
+	Database() string
+	Table() string
+
+	// PrimaryKeyValues returns the value of each column in the table's
+	// primary key, in column order.
+	PrimaryKeyValues() []interface{}
+
+	// OldValues is nil for an INSERT (including the synthetic inserts the
+	// DataIterator emits for the initial snapshot).
+	OldValues() map[string]interface{}
+	// NewValues is nil for a DELETE.
+	NewValues() map[string]interface{}
+
+	// Position identifies where in the source this event came from: a
+	// binlog coordinate or GTID, depending on Config.PositionMode. It is
+	// the zero BinlogPosition for synthetic row-copy events.
+	Position() BinlogPosition
+	GTIDSet() string
+
+	Timestamp() time.Time
+}
+
+// rowEvent is the shared representation behind every concrete DMLEvent:
+// RowInsertEvent (the DataIterator's synthetic snapshot rows) and
+// BinlogDMLEvent (rows mutated by statements the BinlogStreamer tails off
+// the source's binlog).
+type rowEvent struct {
+	schema string
+	table  string
+	pk     []string
+
+	oldValues map[string]interface{}
+	newValues map[string]interface{}
+
+	position BinlogPosition
+	gtidSet  string
+	ts       time.Time
+}
+
+func (e *rowEvent) Database() string                  { return e.schema }
+func (e *rowEvent) Table() string                     { return e.table }
+func (e *rowEvent) OldValues() map[string]interface{} { return e.oldValues }
+func (e *rowEvent) NewValues() map[string]interface{} { return e.newValues }
+func (e *rowEvent) Position() BinlogPosition          { return e.position }
+func (e *rowEvent) GTIDSet() string                   { return e.gtidSet }
+func (e *rowEvent) Timestamp() time.Time              { return e.ts }
+
+func (e *rowEvent) PrimaryKeyValues() []interface{} {
+	values := e.newValues
+	if values == nil {
+		values = e.oldValues
+	}
+
+	pk := make([]interface{}, len(e.pk))
+	for i, col := range e.pk {
+		pk[i] = values[col]
+	}
+	return pk
+}
+
+func (e *rowEvent) AsSQLQuery(tables TableSchemaCache) (string, []interface{}, error) {
+	table, ok := tables[e.schema+"."+e.table]
+	if !ok {
+		return "", nil, fmt.Errorf("unknown table %s.%s", e.schema, e.table)
+	}
+
+	if e.newValues != nil {
+		return buildUpsertQuery(table, e.newValues)
+	}
+	return buildDeleteQuery(table, e.oldValues)
+}
+
+// RowInsertEvent is the synthetic INSERT the DataIterator emits for every
+// row it reads during the initial snapshot. It carries no binlog position
+// or GTID since it didn't come from the binlog.
+type RowInsertEvent struct {
+	*rowEvent
+}
+
+func NewRowInsertEvent(table *TableSchema, values map[string]interface{}) *RowInsertEvent {
+	return &RowInsertEvent{&rowEvent{
+		schema:    table.Schema,
+		table:     table.Name,
+		pk:        table.PrimaryKey,
+		newValues: values,
+		ts:        time.Now(),
+	}}
+}
+
+// BinlogDMLEvent is a row-level change read off the source's binlog: an
+// INSERT (oldValues nil), UPDATE (both set), or DELETE (newValues nil).
+type BinlogDMLEvent struct {
+	*rowEvent
+}
+
+func NewBinlogDMLEvent(table *TableSchema, oldValues, newValues map[string]interface{}, position BinlogPosition, gtidSet string) *BinlogDMLEvent {
+	return &BinlogDMLEvent{&rowEvent{
+		schema:    table.Schema,
+		table:     table.Name,
+		pk:        table.PrimaryKey,
+		oldValues: oldValues,
+		newValues: newValues,
+		position:  position,
+		gtidSet:   gtidSet,
+		ts:        time.Now(),
+	}}
+}
+
+func backtickedColumns(columns []string) []string {
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = fmt.Sprintf("`%s`", col)
+	}
+	return quoted
+}
+
+// buildUpsertQuery renders values as an INSERT ... ON DUPLICATE KEY UPDATE,
+// so the same query shape handles both a synthetic row-copy insert and a
+// replayed binlog INSERT/UPDATE without needing to know which one it is.
+func buildUpsertQuery(table *TableSchema, values map[string]interface{}) (string, []interface{}, error) {
+	columns := table.Columns
+	if len(columns) == 0 {
+		return "", nil, fmt.Errorf("table %s.%s has no columns to write", table.Schema, table.Name)
+	}
+
+	placeholders := make([]string, len(columns))
+	assignments := make([]string, len(columns))
+	args := make([]interface{}, len(columns))
+	for i, col := range columns {
+		placeholders[i] = "?"
+		assignments[i] = fmt.Sprintf("`%s` = VALUES(`%s`)", col, col)
+		args[i] = values[col]
+	}
+
+	query := fmt.Sprintf(
+		"INSERT INTO `%s`.`%s` (%s) VALUES (%s) ON DUPLICATE KEY UPDATE %s",
+		table.Schema, table.Name,
+		strings.Join(backtickedColumns(columns), ", "),
+		strings.Join(placeholders, ", "),
+		strings.Join(assignments, ", "),
+	)
+
+	return query, args, nil
+}
+
+func buildDeleteQuery(table *TableSchema, oldValues map[string]interface{}) (string, []interface{}, error) {
+	if len(table.PrimaryKey) == 0 {
+		return "", nil, fmt.Errorf("table %s.%s has no primary key to delete by", table.Schema, table.Name)
+	}
+
+	conditions := make([]string, len(table.PrimaryKey))
+	args := make([]interface{}, len(table.PrimaryKey))
+	for i, col := range table.PrimaryKey {
+		conditions[i] = fmt.Sprintf("`%s` = ?", col)
+		args[i] = oldValues[col]
+	}
+
+	query := fmt.Sprintf(
+		"DELETE FROM `%s`.`%s` WHERE %s",
+		table.Schema, table.Name, strings.Join(conditions, " AND "),
+	)
+
+	return query, args, nil
+}
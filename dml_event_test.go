@@ -0,0 +1,92 @@
+package ghostferry
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testTable() *TableSchema {
+	return &TableSchema{
+		Schema:     "gftest",
+		Name:       "users",
+		Columns:    []string{"id", "name"},
+		PrimaryKey: []string{"id"},
+	}
+}
+
+func TestRowInsertEventAsSQLQuery(t *testing.T) {
+	table := testTable()
+	tables := TableSchemaCache{"gftest.users": table}
+
+	ev := NewRowInsertEvent(table, map[string]interface{}{"id": int64(1), "name": "alice"})
+
+	query, args, err := ev.AsSQLQuery(tables)
+	if err != nil {
+		t.Fatalf("AsSQLQuery failed: %v", err)
+	}
+
+	wantQuery := "INSERT INTO `gftest`.`users` (`id`, `name`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `id` = VALUES(`id`), `name` = VALUES(`name`)"
+	if query != wantQuery {
+		t.Fatalf("got query %q, want %q", query, wantQuery)
+	}
+
+	wantArgs := []interface{}{int64(1), "alice"}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Fatalf("got args %v, want %v", args, wantArgs)
+	}
+
+	if ev.OldValues() != nil {
+		t.Fatal("a synthetic insert should have no old values")
+	}
+	if got := ev.PrimaryKeyValues(); !reflect.DeepEqual(got, []interface{}{int64(1)}) {
+		t.Fatalf("got primary key %v, want [1]", got)
+	}
+}
+
+func TestBinlogDMLEventDeleteAsSQLQuery(t *testing.T) {
+	table := testTable()
+	tables := TableSchemaCache{"gftest.users": table}
+
+	ev := NewBinlogDMLEvent(table, map[string]interface{}{"id": int64(1), "name": "alice"}, nil, BinlogPosition{File: "mysql-bin.000001", Position: 4}, "")
+
+	query, args, err := ev.AsSQLQuery(tables)
+	if err != nil {
+		t.Fatalf("AsSQLQuery failed: %v", err)
+	}
+
+	wantQuery := "DELETE FROM `gftest`.`users` WHERE `id` = ?"
+	if query != wantQuery {
+		t.Fatalf("got query %q, want %q", query, wantQuery)
+	}
+
+	wantArgs := []interface{}{int64(1)}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Fatalf("got args %v, want %v", args, wantArgs)
+	}
+
+	if ev.NewValues() != nil {
+		t.Fatal("a delete event should have no new values")
+	}
+	if ev.Position() != (BinlogPosition{File: "mysql-bin.000001", Position: 4}) {
+		t.Fatalf("unexpected position %+v", ev.Position())
+	}
+}
+
+func TestRowEventAsSQLQueryUnknownTable(t *testing.T) {
+	table := testTable()
+	ev := NewRowInsertEvent(table, map[string]interface{}{"id": int64(1), "name": "alice"})
+
+	if _, _, err := ev.AsSQLQuery(TableSchemaCache{}); err == nil {
+		t.Fatal("expected an error for a table missing from the cache")
+	}
+}
+
+func TestRowToValues(t *testing.T) {
+	table := testTable()
+	values := rowToValues(table, []interface{}{int64(7), "bob"})
+
+	want := map[string]interface{}{"id": int64(7), "name": "bob"}
+	if !reflect.DeepEqual(values, want) {
+		t.Fatalf("got %v, want %v", values, want)
+	}
+}
@@ -0,0 +1,74 @@
+package ghostferry
+
+import (
+	"context"
+	"os"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrorHandler centralizes fatal errors reported by any other subsystem.
+// The first error reported wins; subsequent subsystems are expected to
+// observe Ctx being cancelled and shut themselves down.
+type ErrorHandler struct {
+	Ferry *Ferry
+
+	Ctx context.Context
+
+	mutex  sync.Mutex
+	err    error
+	errCh  chan struct{}
+	logger *logrus.Entry
+}
+
+func (e *ErrorHandler) Initialize() {
+	e.logger = logrus.WithField("tag", "error_handler")
+	e.errCh = make(chan struct{})
+
+	if e.Ctx == nil {
+		e.Ctx = context.Background()
+	}
+}
+
+// ReportError records a fatal error from another subsystem and cancels
+// Ferry's shared context so every other Runner - which all hold that same
+// Ctx, not a private derivative of it - observes the shutdown uniformly.
+func (e *ErrorHandler) ReportError(from string, err error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.err != nil {
+		return
+	}
+
+	e.err = err
+	e.logger.WithError(err).WithField("source", from).Error("fatal error reported")
+	close(e.errCh)
+
+	if e.Ferry != nil {
+		e.Ferry.cancel()
+	}
+}
+
+func (e *ErrorHandler) Error() error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.err
+}
+
+// Run implements Runner. It simply waits for either a reported error or an
+// external signal/cancellation, at which point the OrderedGroup unwinds the
+// rest of the subsystems.
+func (e *ErrorHandler) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	close(ready)
+
+	select {
+	case <-e.errCh:
+		return e.Error()
+	case <-signals:
+		return nil
+	case <-e.Ctx.Done():
+		return nil
+	}
+}
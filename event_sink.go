@@ -0,0 +1,15 @@
+package ghostferry
+
+import "context"
+
+// EventSink is where Ghostferry delivers the DML events it generates,
+// whether synthesized by the DataIterator during the initial row copy or
+// read off the source's binlog during the tailing phase. MySQLEventSink,
+// which applies events to TargetDB, is the default and only built-in sink
+// for a plain migration; KafkaEventSink is a second built-in sink that lets
+// the same run double as a one-shot change-data-capture seed.
+type EventSink interface {
+	WriteEvents(ctx context.Context, events []DMLEvent) error
+	Flush() error
+	Close() error
+}
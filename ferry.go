@@ -1,12 +1,16 @@
 package ghostferry
 
 import (
+	"context"
 	"crypto/tls"
 	"database/sql"
 	"fmt"
-	"sync"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
+	gomysql "github.com/go-mysql-org/go-mysql/mysql"
 	"github.com/go-sql-driver/mysql"
 	"github.com/sirupsen/logrus"
 )
@@ -34,16 +38,38 @@ type Ferry struct {
 
 	Tables TableSchemaCache
 
+	// EventSink receives every DML event generated by the DataIterator and
+	// BinlogStreamer. It defaults to a MySQLEventSink writing to TargetDB;
+	// set it before calling Initialize to use a different sink, such as a
+	// KafkaEventSink.
+	EventSink EventSink
+
+	// StateTracker holds the last committed resumable position (binlog
+	// file/offset or GTID set, depending on Config.PositionMode) so that a
+	// crashed ferry can be restarted without recopying already-applied data.
+	StateTracker *StateTracker
+
+	// StateStore, when set by the caller before Initialize, persists
+	// StateTracker's position to disk or S3 so a crashed ferry can resume
+	// against it. Checkpointing happens periodically during Run.
+	StateStore *StateStore
+
 	StartTime    time.Time
 	DoneTime     time.Time
 	OverallState string
 
 	logger *logrus.Entry
 
-	coreServicesWg       *sync.WaitGroup
-	supportingServicesWg *sync.WaitGroup
-	controlServerWg      *sync.WaitGroup
-	rowCopyCompleteCh    chan struct{}
+	// ctx/cancel are propagated to every subsystem as Ctx so that
+	// cancellation is uniform, rather than each component defining its own
+	// Stop() method.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	group             *OrderedGroup
+	controlServerDone chan struct{}
+	runErr            error
+	rowCopyCompleteCh chan struct{}
 }
 
 // Initialize all the components of Ghostferry and connect to the Database
@@ -51,11 +77,40 @@ func (f *Ferry) Initialize() (err error) {
 	f.StartTime = time.Now().Truncate(time.Second)
 	f.OverallState = StateStarting
 
-	f.coreServicesWg = &sync.WaitGroup{}
-	f.supportingServicesWg = &sync.WaitGroup{}
-	f.controlServerWg = &sync.WaitGroup{}
 	f.logger = logrus.WithField("tag", "ferry")
 	f.rowCopyCompleteCh = make(chan struct{})
+	f.controlServerDone = make(chan struct{})
+	f.ctx, f.cancel = context.WithCancel(context.Background())
+
+	if err = f.Config.Validate(); err != nil {
+		return err
+	}
+
+	f.StateTracker = NewStateTracker()
+
+	if f.StateStore != nil {
+		state, err := f.StateStore.LoadState()
+		if err != nil {
+			f.logger.WithError(err).Error("failed to load persisted state")
+			return err
+		}
+
+		if state != nil {
+			if state.BinlogPosition != nil {
+				f.StateTracker.UpdateLastStoredBinlogPosition(*state.BinlogPosition)
+			}
+			if state.GTIDSet != "" {
+				gtidSet, err := gomysql.ParseGTIDSet(gomysql.MySQLFlavor, state.GTIDSet)
+				if err != nil {
+					f.logger.WithError(err).Error("failed to parse persisted gtid set")
+					return err
+				}
+				f.StateTracker.UpdateLastStoredGTIDSet(gtidSet)
+			}
+
+			f.logger.Info("resuming from persisted state")
+		}
+	}
 
 	sourceConfig := &mysql.Config{
 		User:   f.SourceUser,
@@ -137,6 +192,7 @@ func (f *Ferry) Initialize() (err error) {
 	// Initialize the ErrorHandler
 	f.ErrorHandler = &ErrorHandler{
 		Ferry: f,
+		Ctx:   f.ctx,
 	}
 	f.ErrorHandler.Initialize()
 
@@ -144,6 +200,7 @@ func (f *Ferry) Initialize() (err error) {
 		Db:           f.SourceDB,
 		Config:       f.Config,
 		ErrorHandler: f.ErrorHandler,
+		Ctx:          f.ctx,
 	}
 	f.Throttler.Initialize()
 
@@ -153,6 +210,8 @@ func (f *Ferry) Initialize() (err error) {
 		Config:       f.Config,
 		ErrorHandler: f.ErrorHandler,
 		Throttler:    f.Throttler,
+		StateTracker: f.StateTracker,
+		Ctx:          f.ctx,
 	}
 	err = f.BinlogStreamer.Initialize()
 	if err != nil {
@@ -165,6 +224,7 @@ func (f *Ferry) Initialize() (err error) {
 		Config:       f.Config,
 		ErrorHandler: f.ErrorHandler,
 		Throttler:    f.Throttler,
+		Ctx:          f.ctx,
 	}
 
 	err = f.DataIterator.Initialize()
@@ -177,6 +237,7 @@ func (f *Ferry) Initialize() (err error) {
 		F:       f,
 		Addr:    f.Config.ServerBindAddr,
 		Basedir: f.Config.WebBasedir,
+		Ctx:     f.ctx,
 	}
 
 	err = f.ControlServer.Initialize()
@@ -184,6 +245,14 @@ func (f *Ferry) Initialize() (err error) {
 		return err
 	}
 
+	if f.Verifier == nil {
+		f.Verifier = NoVerifier{}
+	}
+
+	if f.EventSink == nil {
+		f.EventSink = NewMySQLEventSink(f.TargetDB, f.MaxWriteRetriesOnTargetDBError)
+	}
+
 	f.logger.Info("ferry initialized")
 
 	return nil
@@ -199,8 +268,8 @@ func (f *Ferry) Start() error {
 	// Registering the builtin event listeners in Start allows the consumer
 	// of the library to register event listeners that gets called before
 	// and after the data gets written to the target database.
-	f.BinlogStreamer.AddEventListener(f.writeEventsToTargetWithRetries)
-	f.DataIterator.AddEventListener(f.writeEventsToTargetWithRetries)
+	f.BinlogStreamer.AddEventListener(f.writeEventsToSink)
+	f.DataIterator.AddEventListener(f.writeEventsToSink)
 	f.DataIterator.AddDoneListener(f.onFinishedIterations)
 
 	// The starting binlog coordinates must be determined first. If it is
@@ -224,44 +293,72 @@ func (f *Ferry) Start() error {
 	}
 
 	f.DataIterator.Tables = f.Tables.AsSlice()
+	f.BinlogStreamer.Tables = f.Tables
+
+	if sink, ok := f.EventSink.(*MySQLEventSink); ok {
+		sink.Tables = f.Tables
+	}
 
 	return nil
 }
 
-// Spawns the background tasks that actually perform the run.
-// Wait for the background tasks to finish.
+// Run starts every subsystem as a Member of an OrderedGroup, in the order
+// the components depend on each other: the ErrorHandler first (so it can
+// observe failures from everything else), then the Throttler, then the
+// BinlogStreamer, DataIterator, Verifier, and finally the ControlServer.
+// SIGINT/SIGTERM are forwarded into the group, which stops every member in
+// the reverse order, so the ControlServer's lame-duck period runs before the
+// BinlogStreamer stops. Run blocks until every subsystem has exited.
 func (f *Ferry) Run() {
 	f.logger.Info("starting ferry run")
 	f.OverallState = StateCopying
 
-	f.controlServerWg.Add(1)
-	go f.ControlServer.Run(f.controlServerWg)
+	if f.StateStore != nil {
+		go f.checkpointStateLoop()
+	}
 
-	f.coreServicesWg.Add(2)
-	go f.BinlogStreamer.Run(f.coreServicesWg)
-	go f.DataIterator.Run(f.coreServicesWg)
+	f.group = NewOrderedGroup(
+		Member{Name: "error_handler", Runner: RunFunc(f.ErrorHandler.Run)},
+		Member{Name: "throttler", Runner: RunFunc(f.Throttler.Run)},
+		Member{Name: "binlog_streamer", Runner: RunFunc(f.BinlogStreamer.Run)},
+		Member{Name: "data_iterator", Runner: RunFunc(f.DataIterator.Run)},
+		Member{Name: "verifier", Runner: RunFunc(f.Verifier.Run)},
+		Member{Name: "control_server", Runner: f.controlServerMember()},
+	)
 
-	f.supportingServicesWg.Add(2)
-	go f.ErrorHandler.Run(f.supportingServicesWg)
-	go f.Throttler.Run(f.supportingServicesWg)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
 
-	f.coreServicesWg.Wait()
+	ready := make(chan struct{})
+	f.runErr = f.group.Run(signals, ready)
 
+	f.cancel()
 	f.OverallState = StateDone
 	f.DoneTime = time.Now()
 
-	// Need to wait to ensure that the ErrorHandler does not get
-	// interrupted if it is received some errors, have not printed it
-	// out, but all other threads (including the main thread) has quit.
-	// Without some sort of waiting on the main thread for the
-	// ErrorHandler to exit first, the program could exit without ever
-	// printing out the error and panicking.
-	//
-	// Furthermore, in a normal run without errors we need to ensure this
-	// shuts down and does not block forever.
-	f.ErrorHandler.Stop()
-	f.Throttler.Stop()
-	f.supportingServicesWg.Wait()
+	if f.runErr != nil {
+		f.logger.WithError(f.runErr).Error("ferry run exited with an error")
+	}
+
+	if err := f.EventSink.Flush(); err != nil {
+		f.logger.WithError(err).Warn("failed to flush event sink")
+	}
+
+	if err := f.EventSink.Close(); err != nil {
+		f.logger.WithError(err).Warn("failed to close event sink")
+	}
+}
+
+// controlServerMember wraps the ControlServer's Run so that
+// WaitForControlServer/controlServerDone keep working even though the
+// ControlServer is now just another OrderedGroup member rather than owning
+// its own WaitGroup.
+func (f *Ferry) controlServerMember() RunFunc {
+	return func(signals <-chan os.Signal, ready chan<- struct{}) error {
+		defer close(f.controlServerDone)
+		return f.ControlServer.Run(signals, ready)
+	}
 }
 
 // Call this method and perform the cutover after this method returns.
@@ -284,7 +381,7 @@ func (f *Ferry) FlushBinlogAndStopStreaming() {
 // its finish.
 func (f *Ferry) WaitForControlServer() {
 	f.logger.Warn("waiting for control server...")
-	f.controlServerWg.Wait()
+	<-f.controlServerDone
 }
 
 // This method is used to shutdown the ControlServer. By default,
@@ -299,6 +396,44 @@ func (f *Ferry) ShutdownControlServer() error {
 	return err
 }
 
+// checkpointStateLoop periodically persists the current resumable position
+// to the StateStore until the ferry is done, so a crash doesn't force the
+// next run to recopy everything from scratch.
+func (f *Ferry) checkpointStateLoop() {
+	interval := f.Config.CheckpointInterval
+	if interval == 0 {
+		interval = 10 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := f.checkpointState(); err != nil {
+				f.logger.WithError(err).Error("failed to checkpoint state")
+			}
+		case <-f.ctx.Done():
+			return
+		}
+	}
+}
+
+func (f *Ferry) checkpointState() error {
+	state := &persistedState{}
+
+	if f.Config.PositionMode == PositionModeGTID {
+		if gtidSet := f.StateTracker.LastStoredGTIDSet(); gtidSet != nil {
+			state.GTIDSet = gtidSet.String()
+		}
+	} else if pos, ok := f.StateTracker.LastStoredBinlogPosition(); ok {
+		state.BinlogPosition = &pos
+	}
+
+	return f.StateStore.SaveState(state)
+}
+
 func (f *Ferry) onFinishedIterations() error {
 	f.logger.Info("finished iterations")
 	f.OverallState = StateWaitingForCutover
@@ -316,57 +451,8 @@ func (f *Ferry) onFinishedIterations() error {
 	return nil
 }
 
-func (f *Ferry) writeEventsToTargetWithRetries(events []DMLEvent) error {
-	var err error
-	for i := 0; i < f.MaxWriteRetriesOnTargetDBError; i++ {
-		err = f.writeEventsToTarget(events)
-		if err == nil {
-			return nil
-		} else {
-			f.logger.WithError(err).Error("failed to write event to target")
-		}
-	}
-
-	f.logger.Error("failed to write events to target even after retries")
-	return err
-}
-
-func (f *Ferry) writeEventsToTarget(events []DMLEvent) error {
-	tx, err := f.TargetDB.Begin()
-	if err != nil {
-		return err
-	}
-	rollback := func(err error) error {
-		tx.Rollback()
-		return err
-	}
-
-	sessionQuery := `
-		SET SESSION time_zone = '+00:00',
-		sql_mode = CONCAT(@@session.sql_mode, ',STRICT_ALL_TABLES')
-	`
-
-	_, err = tx.Exec(sessionQuery)
-	if err != nil {
-		err = fmt.Errorf("during setting session: %v", err)
-		return rollback(err)
-	}
-
-	for _, ev := range events {
-		sql, args, err := ev.AsSQLQuery(f.Tables)
-		if err != nil {
-			err = fmt.Errorf("during generating sql query: %v", err)
-			return rollback(err)
-		}
-
-		_, err = tx.Exec(sql, args...)
-		if err != nil {
-			err = fmt.Errorf("during exec query (%s %v): %v", sql, args, err)
-			return rollback(err)
-		}
-	}
-
-	return tx.Commit()
+func (f *Ferry) writeEventsToSink(events []DMLEvent) error {
+	return f.EventSink.WriteEvents(f.ctx, events)
 }
 
 func checkConnection(logger *logrus.Entry, dsn string, db *sql.DB) error {
@@ -0,0 +1,73 @@
+package ghostferry
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/go-mysql-org/go-mysql/replication"
+)
+
+// sidFromUUID turns a canonical "xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx" UUID
+// string into the raw 16-byte SID a GTIDEvent carries on the wire.
+func sidFromUUID(t *testing.T, uuid string) []byte {
+	t.Helper()
+
+	raw, err := hex.DecodeString(strings.ReplaceAll(uuid, "-", ""))
+	if err != nil {
+		t.Fatalf("failed to decode test uuid %q: %v", uuid, err)
+	}
+	return raw
+}
+
+func TestGtidFromEventReturnsTheEventsGTIDSet(t *testing.T) {
+	uuid := "3e11fa47-71ca-11e1-9e33-c80aa9429562"
+	event := &replication.GTIDEvent{
+		SID: sidFromUUID(t, uuid),
+		GNO: 42,
+	}
+
+	gtidSet, err := gtidFromEvent(event)
+	if err != nil {
+		t.Fatalf("gtidFromEvent failed: %v", err)
+	}
+
+	want := uuid + ":42"
+	if gtidSet.String() != want {
+		t.Fatalf("got %q, want %q", gtidSet.String(), want)
+	}
+}
+
+func TestAdvanceGTIDSetDetectsAlreadyAppliedTransactions(t *testing.T) {
+	uuid := "3e11fa47-71ca-11e1-9e33-c80aa9429562"
+	s := &BinlogStreamer{Config: &Config{PositionMode: PositionModeGTID}}
+
+	first := &replication.GTIDEvent{SID: sidFromUUID(t, uuid), GNO: 1}
+	alreadyApplied, err := s.AdvanceGTIDSet(first)
+	if err != nil {
+		t.Fatalf("AdvanceGTIDSet failed: %v", err)
+	}
+	if alreadyApplied {
+		t.Fatal("the first time a GTID is seen it should not be reported as already applied")
+	}
+
+	// Seeing the exact same GTID again (e.g. because we resumed from a
+	// checkpoint at or after it) must be recognized as already applied.
+	replay := &replication.GTIDEvent{SID: sidFromUUID(t, uuid), GNO: 1}
+	alreadyApplied, err = s.AdvanceGTIDSet(replay)
+	if err != nil {
+		t.Fatalf("AdvanceGTIDSet failed: %v", err)
+	}
+	if !alreadyApplied {
+		t.Fatal("replaying a GTID already in the set should be reported as already applied")
+	}
+
+	next := &replication.GTIDEvent{SID: sidFromUUID(t, uuid), GNO: 2}
+	alreadyApplied, err = s.AdvanceGTIDSet(next)
+	if err != nil {
+		t.Fatalf("AdvanceGTIDSet failed: %v", err)
+	}
+	if alreadyApplied {
+		t.Fatal("a new GNO should not be reported as already applied")
+	}
+}
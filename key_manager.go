@@ -0,0 +1,50 @@
+package ghostferry
+
+import "fmt"
+
+// KeyManager holds the keys used to encrypt and decrypt persisted state. It
+// distinguishes between the single ActiveLabel, whose key is used to encrypt
+// new state, and a broader set of labels whose keys are kept around only to
+// decrypt state written under a previous key. This lets an operator rotate
+// keys by introducing a new active label, running a rekey pass
+// (PerformEncryption), and only then retiring the old label.
+type KeyManager struct {
+	ActiveLabel string
+	Keys        map[string][]byte // label -> AES-256 key
+}
+
+func NewKeyManager(activeLabel string, keys map[string][]byte) *KeyManager {
+	return &KeyManager{
+		ActiveLabel: activeLabel,
+		Keys:        keys,
+	}
+}
+
+// ActiveKey returns the label and key that should be used to encrypt new
+// state.
+func (k *KeyManager) ActiveKey() (label string, key []byte, err error) {
+	key, err = k.KeyForLabel(k.ActiveLabel)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return k.ActiveLabel, key, nil
+}
+
+// KeyForLabel returns the decryption key registered under label, including
+// labels that are no longer active. This is used when decrypting state that
+// was encrypted under a previous key.
+func (k *KeyManager) KeyForLabel(label string) ([]byte, error) {
+	key, ok := k.Keys[label]
+	if !ok {
+		return nil, fmt.Errorf("no key registered for label %q", label)
+	}
+
+	return key, nil
+}
+
+// IsActive reports whether label is the currently active encryption label.
+// PerformEncryption uses this to find state that needs to be rewritten.
+func (k *KeyManager) IsActive(label string) bool {
+	return label == k.ActiveLabel
+}
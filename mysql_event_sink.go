@@ -0,0 +1,86 @@
+package ghostferry
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// MySQLEventSink applies DML events to a MySQL target database. It is
+// Ghostferry's original, and default, EventSink implementation.
+type MySQLEventSink struct {
+	TargetDB *sql.DB
+	Tables   TableSchemaCache
+
+	MaxWriteRetriesOnTargetDBError int
+
+	logger *logrus.Entry
+}
+
+func NewMySQLEventSink(targetDB *sql.DB, maxRetries int) *MySQLEventSink {
+	return &MySQLEventSink{
+		TargetDB:                       targetDB,
+		MaxWriteRetriesOnTargetDBError: maxRetries,
+		logger:                         logrus.WithField("tag", "mysql_event_sink"),
+	}
+}
+
+func (s *MySQLEventSink) WriteEvents(ctx context.Context, events []DMLEvent) error {
+	var err error
+	for i := 0; i < s.MaxWriteRetriesOnTargetDBError; i++ {
+		err = s.writeEventsOnce(events)
+		if err == nil {
+			return nil
+		}
+		s.logger.WithError(err).Error("failed to write event to target")
+	}
+
+	s.logger.Error("failed to write events to target even after retries")
+	return err
+}
+
+func (s *MySQLEventSink) writeEventsOnce(events []DMLEvent) error {
+	tx, err := s.TargetDB.Begin()
+	if err != nil {
+		return err
+	}
+	rollback := func(err error) error {
+		tx.Rollback()
+		return err
+	}
+
+	sessionQuery := `
+		SET SESSION time_zone = '+00:00',
+		sql_mode = CONCAT(@@session.sql_mode, ',STRICT_ALL_TABLES')
+	`
+
+	_, err = tx.Exec(sessionQuery)
+	if err != nil {
+		err = fmt.Errorf("during setting session: %v", err)
+		return rollback(err)
+	}
+
+	for _, ev := range events {
+		sql, args, err := ev.AsSQLQuery(s.Tables)
+		if err != nil {
+			err = fmt.Errorf("during generating sql query: %v", err)
+			return rollback(err)
+		}
+
+		_, err = tx.Exec(sql, args...)
+		if err != nil {
+			err = fmt.Errorf("during exec query (%s %v): %v", sql, args, err)
+			return rollback(err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Flush is a no-op: MySQLEventSink writes and commits synchronously in
+// WriteEvents, so there is nothing buffered to flush.
+func (s *MySQLEventSink) Flush() error { return nil }
+
+func (s *MySQLEventSink) Close() error { return nil }
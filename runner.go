@@ -0,0 +1,131 @@
+package ghostferry
+
+import (
+	"fmt"
+	"os"
+)
+
+// Runner is implemented by every long-running Ferry subsystem
+// (ErrorHandler, Throttler, ControlServer, BinlogStreamer, DataIterator,
+// Verifier). Run blocks until the subsystem exits, either because it
+// received a signal on signals or because it failed on its own. Once a
+// Runner has finished any blocking startup work and is ready to be
+// considered "up", it must close ready exactly once.
+type Runner interface {
+	Run(signals <-chan os.Signal, ready chan<- struct{}) error
+}
+
+// RunFunc adapts a plain function to the Runner interface.
+type RunFunc func(signals <-chan os.Signal, ready chan<- struct{}) error
+
+func (f RunFunc) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	return f(signals, ready)
+}
+
+// Member names a Runner for inclusion in an OrderedGroup so that startup
+// failures and shutdown logging can identify which subsystem they came
+// from.
+type Member struct {
+	Name   string
+	Runner Runner
+}
+
+// orderedGroupMember tracks a running Member. done is closed exactly once,
+// after err has been written, so it can be observed any number of times
+// (by the startup select, waitForAny, and stop) without anyone having to
+// worry about who "consumes" the result.
+type orderedGroupMember struct {
+	Member
+	signals chan os.Signal
+	done    chan struct{}
+	err     error
+}
+
+// OrderedGroup runs a fixed list of Members to completion, starting them in
+// order and waiting for each to become ready before starting the next, then
+// stopping them in the reverse order once a signal is received or any
+// member exits on its own. Startup order should reflect dependency order
+// (e.g. the ErrorHandler starts before the components that report to it);
+// shutdown unwinds in the opposite direction. This mirrors ifrit's
+// grouper.NewOrdered.
+type OrderedGroup struct {
+	Members []Member
+}
+
+func NewOrderedGroup(members ...Member) *OrderedGroup {
+	return &OrderedGroup{Members: members}
+}
+
+func (g *OrderedGroup) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	var started []*orderedGroupMember
+
+	for _, m := range g.Members {
+		om := &orderedGroupMember{
+			Member:  m,
+			signals: make(chan os.Signal, 1),
+			done:    make(chan struct{}),
+		}
+		readyCh := make(chan struct{})
+
+		go func(om *orderedGroupMember) {
+			om.err = om.Runner.Run(om.signals, readyCh)
+			close(om.done)
+		}(om)
+
+		select {
+		case <-readyCh:
+			started = append(started, om)
+		case <-om.done:
+			// A member that closed ready and then exited on its own may have
+			// both channels closed by now; it still counts as started.
+			select {
+			case <-readyCh:
+				started = append(started, om)
+				continue
+			default:
+			}
+			g.stop(started)
+			return fmt.Errorf("%s exited before becoming ready: %v", m.Name, om.err)
+		}
+	}
+
+	if ready != nil {
+		close(ready)
+	}
+
+	var runErr error
+	select {
+	case <-signals:
+	case exited := <-waitForAny(started):
+		runErr = exited.err
+	}
+
+	g.stop(started)
+	return runErr
+}
+
+// waitForAny fans the members' done channels into a single channel carrying
+// whichever member exited first, so OrderedGroup.Run can select on "any
+// member exited" without knowing ahead of time which one. Since done is
+// only ever closed (never sent on), every goroutine here can observe it
+// independently of stop() also observing it later.
+func waitForAny(members []*orderedGroupMember) <-chan *orderedGroupMember {
+	out := make(chan *orderedGroupMember, len(members))
+	for _, om := range members {
+		go func(om *orderedGroupMember) {
+			<-om.done
+			out <- om
+		}(om)
+	}
+	return out
+}
+
+func (g *OrderedGroup) stop(started []*orderedGroupMember) {
+	for i := len(started) - 1; i >= 0; i-- {
+		select {
+		case started[i].signals <- os.Interrupt:
+		default:
+		}
+		<-started[i].done
+	}
+}
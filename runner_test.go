@@ -0,0 +1,71 @@
+package ghostferry
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func immediateRunner() RunFunc {
+	return func(signals <-chan os.Signal, ready chan<- struct{}) error {
+		close(ready)
+		return nil
+	}
+}
+
+func blockingRunner() RunFunc {
+	return func(signals <-chan os.Signal, ready chan<- struct{}) error {
+		close(ready)
+		<-signals
+		return nil
+	}
+}
+
+// TestOrderedGroupMemberExitingOnItsOwnDoesNotDeadlock reproduces a group
+// where one member returns immediately (as DataIterator.Run does once row
+// copy finishes) while another keeps running until signaled. Run must
+// still return promptly instead of hanging in stop().
+func TestOrderedGroupMemberExitingOnItsOwnDoesNotDeadlock(t *testing.T) {
+	group := NewOrderedGroup(
+		Member{Name: "immediate", Runner: immediateRunner()},
+		Member{Name: "blocking", Runner: blockingRunner()},
+	)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- group.Run(make(chan os.Signal), make(chan struct{}))
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("OrderedGroup.Run deadlocked after a member exited on its own")
+	}
+}
+
+func TestOrderedGroupStopsOnSignal(t *testing.T) {
+	group := NewOrderedGroup(
+		Member{Name: "a", Runner: blockingRunner()},
+		Member{Name: "b", Runner: blockingRunner()},
+	)
+
+	signals := make(chan os.Signal, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- group.Run(signals, make(chan struct{}))
+	}()
+
+	signals <- os.Interrupt
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("OrderedGroup.Run did not stop after a signal")
+	}
+}
@@ -0,0 +1,72 @@
+package ghostferry
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// S3StateStoreBackend persists state entries as objects under Prefix in an
+// S3 bucket, for operators who run Ghostferry across ephemeral hosts and
+// need the checkpoint to survive past any single host's lifetime.
+type S3StateStoreBackend struct {
+	Bucket string
+	Prefix string
+
+	S3 *s3.S3
+}
+
+func (s *S3StateStoreBackend) key(key string) string {
+	return fmt.Sprintf("%s/%s", s.Prefix, key)
+}
+
+func (s *S3StateStoreBackend) List() ([]string, error) {
+	var keys []string
+
+	err := s.S3.ListObjectsPages(&s3.ListObjectsInput{
+		Bucket: aws.String(s.Bucket),
+		Prefix: aws.String(s.Prefix + "/"),
+	}, func(page *s3.ListObjectsOutput, lastPage bool) bool {
+		for _, obj := range page.Contents {
+			keys = append(keys, (*obj.Key)[len(s.Prefix)+1:])
+		}
+		return true
+	})
+
+	return keys, err
+}
+
+func (s *S3StateStoreBackend) Read(key string) ([]byte, error) {
+	out, err := s.S3.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.key(key)),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok {
+			switch aerr.Code() {
+			case s3.ErrCodeNoSuchKey, "NotFound":
+				return nil, ErrStateNotFound
+			}
+		}
+		return nil, err
+	}
+	defer out.Body.Close()
+
+	return ioutil.ReadAll(out.Body)
+}
+
+func (s *S3StateStoreBackend) Write(key string, data []byte) error {
+	uploader := s3manager.NewUploaderWithClient(s.S3)
+	_, err := uploader.Upload(&s3manager.UploadInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.key(key)),
+		Body:   bytes.NewReader(data),
+	})
+
+	return err
+}
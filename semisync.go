@@ -0,0 +1,24 @@
+package ghostferry
+
+import (
+	"database/sql"
+)
+
+// sourceSupportsSemiSync reports whether the source has semi-sync
+// replication enabled on the master side (rpl_semi_sync_master_enabled).
+// Ghostferry only registers as a semi-sync replica when this is the case;
+// otherwise the source has no semi-sync ACK protocol to participate in.
+func sourceSupportsSemiSync(db *sql.DB) (bool, error) {
+	row := db.QueryRow("SHOW VARIABLES LIKE 'rpl_semi_sync_master_enabled'")
+
+	var name, value string
+	if err := row.Scan(&name, &value); err != nil {
+		if err == sql.ErrNoRows {
+			// The semi-sync plugin isn't installed on the source at all.
+			return false, nil
+		}
+		return false, err
+	}
+
+	return value == "ON", nil
+}
@@ -0,0 +1,177 @@
+package ghostferry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrStateNotFound is returned by a StateStoreBackend's Read when key
+// doesn't exist yet. Every backend must translate its own "not found"
+// condition (a missing file, a NoSuchKey from S3, ...) into this sentinel
+// so that StateStore.LoadState can recognize a fresh run regardless of
+// which backend is in use.
+var ErrStateNotFound = errors.New("ghostferry: state not found")
+
+// persistedState is the serialized shape of everything StateStore needs to
+// resume a run: the BinlogStreamer's position and, eventually, per-table
+// copy progress and verifier state.
+type persistedState struct {
+	BinlogPosition *BinlogPosition `json:"BinlogPosition,omitempty"`
+	GTIDSet        string          `json:"GTIDSet,omitempty"`
+}
+
+// StateStoreBackend persists opaque, already-encrypted blobs under a key.
+// DiskStateStoreBackend and S3StateStoreBackend are the two built-in
+// implementations; either can be swapped in without StateStore itself
+// knowing about encryption or storage details.
+type StateStoreBackend interface {
+	List() ([]string, error)
+	Read(key string) ([]byte, error)
+	Write(key string, data []byte) error
+}
+
+// StateStore persists Ghostferry's resumable state, encrypting every entry
+// with a Cryptor so that the on-disk/S3 representation is never plaintext.
+type StateStore struct {
+	Backend StateStoreBackend
+	Cryptor *Cryptor
+}
+
+const stateStoreKey = "ferry-state"
+
+func (s *StateStore) SaveState(state *persistedState) error {
+	plaintext, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %v", err)
+	}
+
+	ciphertext, err := s.Cryptor.Encrypt(plaintext)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt state: %v", err)
+	}
+
+	return s.Backend.Write(stateStoreKey, ciphertext)
+}
+
+// LoadState returns nil, nil if no state has been persisted yet (i.e. this
+// is a fresh run).
+func (s *StateStore) LoadState() (*persistedState, error) {
+	ciphertext, err := s.Backend.Read(stateStoreKey)
+	if errors.Is(err, ErrStateNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read state: %v", err)
+	}
+
+	plaintext, err := s.Cryptor.Decrypt(ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt state: %v", err)
+	}
+
+	state := &persistedState{}
+	if err := json.Unmarshal(plaintext, state); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal state: %v", err)
+	}
+
+	return state, nil
+}
+
+// PerformEncryption walks every entry in the backend and rewrites any entry
+// that isn't encrypted under the KeyManager's current active label, so that
+// an operator can rotate keys without redoing a copy: roll the active label
+// forward, run PerformEncryption to re-encrypt existing state, then retire
+// the old label's key once it's no longer referenced anywhere.
+func (s *StateStore) PerformEncryption(logger *logrus.Entry) error {
+	keys, err := s.Backend.List()
+	if err != nil {
+		return fmt.Errorf("failed to list state entries: %v", err)
+	}
+
+	for _, key := range keys {
+		payload, err := s.Backend.Read(key)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %v", key, err)
+		}
+
+		label, err := s.Cryptor.Label(payload)
+		if err != nil {
+			return fmt.Errorf("failed to read label for %s: %v", key, err)
+		}
+
+		if s.Cryptor.KeyManager.IsActive(label) {
+			continue
+		}
+
+		plaintext, err := s.Cryptor.Decrypt(payload)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt %s (label %s): %v", key, label, err)
+		}
+
+		reencrypted, err := s.Cryptor.Encrypt(plaintext)
+		if err != nil {
+			return fmt.Errorf("failed to re-encrypt %s: %v", key, err)
+		}
+
+		if err := s.Backend.Write(key, reencrypted); err != nil {
+			return fmt.Errorf("failed to write %s: %v", key, err)
+		}
+
+		logger.WithFields(logrus.Fields{
+			"key":       key,
+			"old_label": label,
+			"new_label": s.Cryptor.KeyManager.ActiveLabel,
+		}).Info("rotated state entry to the active key")
+	}
+
+	return nil
+}
+
+// DiskStateStoreBackend persists state entries as files under Basedir, one
+// file per key.
+type DiskStateStoreBackend struct {
+	Basedir string
+}
+
+func (d *DiskStateStoreBackend) path(key string) string {
+	return filepath.Join(d.Basedir, key)
+}
+
+func (d *DiskStateStoreBackend) List() ([]string, error) {
+	entries, err := ioutil.ReadDir(d.Basedir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			keys = append(keys, entry.Name())
+		}
+	}
+
+	return keys, nil
+}
+
+func (d *DiskStateStoreBackend) Read(key string) ([]byte, error) {
+	data, err := ioutil.ReadFile(d.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrStateNotFound
+	}
+	return data, err
+}
+
+func (d *DiskStateStoreBackend) Write(key string, data []byte) error {
+	if err := os.MkdirAll(d.Basedir, 0700); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(d.path(key), data, 0600)
+}
@@ -0,0 +1,92 @@
+package ghostferry
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+type memoryStateStoreBackend struct {
+	entries map[string][]byte
+}
+
+func newMemoryStateStoreBackend() *memoryStateStoreBackend {
+	return &memoryStateStoreBackend{entries: map[string][]byte{}}
+}
+
+func (m *memoryStateStoreBackend) List() ([]string, error) {
+	keys := make([]string, 0, len(m.entries))
+	for k := range m.entries {
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+func (m *memoryStateStoreBackend) Read(key string) ([]byte, error) {
+	data, ok := m.entries[key]
+	if !ok {
+		return nil, ErrStateNotFound
+	}
+	return data, nil
+}
+
+func (m *memoryStateStoreBackend) Write(key string, data []byte) error {
+	m.entries[key] = data
+	return nil
+}
+
+func TestStateStoreLoadStateReturnsNilOnFreshRun(t *testing.T) {
+	km := NewKeyManager("v1", map[string][]byte{"v1": testKey(1)})
+	store := &StateStore{Backend: newMemoryStateStoreBackend(), Cryptor: NewCryptor(km)}
+
+	state, err := store.LoadState()
+	if err != nil {
+		t.Fatalf("expected no error on a fresh run, got %v", err)
+	}
+	if state != nil {
+		t.Fatalf("expected nil state on a fresh run, got %+v", state)
+	}
+}
+
+func TestStateStorePerformEncryptionRotatesToActiveKey(t *testing.T) {
+	km := NewKeyManager("v1", map[string][]byte{"v1": testKey(1)})
+	backend := newMemoryStateStoreBackend()
+	store := &StateStore{Backend: backend, Cryptor: NewCryptor(km)}
+
+	pos := BinlogPosition{File: "mysql-bin.000001", Position: 4}
+	if err := store.SaveState(&persistedState{BinlogPosition: &pos}); err != nil {
+		t.Fatalf("SaveState failed: %v", err)
+	}
+
+	oldCiphertext := backend.entries[stateStoreKey]
+	oldLabel, err := store.Cryptor.Label(oldCiphertext)
+	if err != nil {
+		t.Fatalf("Label failed: %v", err)
+	}
+	if oldLabel != "v1" {
+		t.Fatalf("expected initial label v1, got %s", oldLabel)
+	}
+
+	km.Keys["v2"] = testKey(2)
+	km.ActiveLabel = "v2"
+
+	if err := store.PerformEncryption(logrus.WithField("tag", "test")); err != nil {
+		t.Fatalf("PerformEncryption failed: %v", err)
+	}
+
+	newLabel, err := store.Cryptor.Label(backend.entries[stateStoreKey])
+	if err != nil {
+		t.Fatalf("Label failed: %v", err)
+	}
+	if newLabel != "v2" {
+		t.Fatalf("expected entry to be rotated to v2, got %s", newLabel)
+	}
+
+	state, err := store.LoadState()
+	if err != nil {
+		t.Fatalf("LoadState after rotation failed: %v", err)
+	}
+	if state == nil || state.BinlogPosition == nil || *state.BinlogPosition != pos {
+		t.Fatalf("expected state to round-trip to %+v, got %+v", pos, state)
+	}
+}
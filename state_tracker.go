@@ -0,0 +1,48 @@
+package ghostferry
+
+import (
+	"sync"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+)
+
+// StateTracker holds the resumable state of a Ghostferry run: the last
+// binlog coordinate/GTID set that has been safely committed to the target.
+// It is consulted on startup so a crashed ferry can restart at the last
+// committed position instead of from scratch.
+type StateTracker struct {
+	mutex sync.Mutex
+
+	lastStoredBinlogPosition BinlogPosition
+	lastStoredGTIDSet        mysql.GTIDSet
+}
+
+func NewStateTracker() *StateTracker {
+	return &StateTracker{}
+}
+
+func (s *StateTracker) UpdateLastStoredBinlogPosition(pos BinlogPosition) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.lastStoredBinlogPosition = pos
+}
+
+func (s *StateTracker) LastStoredBinlogPosition() (BinlogPosition, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.lastStoredBinlogPosition, s.lastStoredBinlogPosition.File != ""
+}
+
+func (s *StateTracker) UpdateLastStoredGTIDSet(gtidSet mysql.GTIDSet) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.lastStoredGTIDSet = gtidSet
+}
+
+// LastStoredGTIDSet returns the GTID set to resume from, or nil if this is
+// a fresh run with no prior state.
+func (s *StateTracker) LastStoredGTIDSet() mysql.GTIDSet {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.lastStoredGTIDSet
+}
@@ -0,0 +1,135 @@
+package ghostferry
+
+import "database/sql"
+
+// TableSchema describes a single table Ghostferry is copying: its columns,
+// primary key, and the database/table name pair identifying it on both the
+// source and target.
+type TableSchema struct {
+	Schema string
+	Name   string
+
+	Columns    []string
+	PrimaryKey []string
+}
+
+// TableSchemaCache maps "database.table" to its loaded TableSchema.
+type TableSchemaCache map[string]*TableSchema
+
+func (c TableSchemaCache) AsSlice() []*TableSchema {
+	tables := make([]*TableSchema, 0, len(c))
+	for _, t := range c {
+		tables = append(tables, t)
+	}
+	return tables
+}
+
+// loadTables reads the schema of every table in applicableDatabases that is
+// also present in applicableTables (or every table in the database when
+// applicableTables is empty) and returns it keyed by "database.table".
+func loadTables(db *sql.DB, applicableDatabases, applicableTables map[string]bool) (TableSchemaCache, error) {
+	cache := TableSchemaCache{}
+
+	databases, err := applicableDatabaseNames(db, applicableDatabases)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dbName := range databases {
+		tableNames, err := tableNamesInDatabase(db, dbName, applicableTables)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, tableName := range tableNames {
+			table, err := loadTableSchema(db, dbName, tableName)
+			if err != nil {
+				return nil, err
+			}
+
+			cache[dbName+"."+tableName] = table
+		}
+	}
+
+	return cache, nil
+}
+
+func applicableDatabaseNames(db *sql.DB, applicableDatabases map[string]bool) ([]string, error) {
+	rows, err := db.Query("SHOW DATABASES")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var databases []string
+	for rows.Next() {
+		var dbName string
+		if err := rows.Scan(&dbName); err != nil {
+			return nil, err
+		}
+
+		if len(applicableDatabases) > 0 && !applicableDatabases[dbName] {
+			continue
+		}
+
+		databases = append(databases, dbName)
+	}
+
+	return databases, rows.Err()
+}
+
+func tableNamesInDatabase(db *sql.DB, dbName string, applicableTables map[string]bool) ([]string, error) {
+	rows, err := db.Query("SHOW TABLES FROM `" + dbName + "`")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var tableName string
+		if err := rows.Scan(&tableName); err != nil {
+			return nil, err
+		}
+
+		if len(applicableTables) > 0 && !applicableTables[tableName] {
+			continue
+		}
+
+		tables = append(tables, tableName)
+	}
+
+	return tables, rows.Err()
+}
+
+// loadTableSchema reads a table's columns, in ordinal position order, and
+// the subset of them that make up its primary key, from information_schema.
+func loadTableSchema(db *sql.DB, schema, table string) (*TableSchema, error) {
+	rows, err := db.Query(
+		`SELECT COLUMN_NAME, COLUMN_KEY
+		   FROM information_schema.columns
+		  WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?
+		  ORDER BY ORDINAL_POSITION`,
+		schema, table,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ts := &TableSchema{Schema: schema, Name: table}
+
+	for rows.Next() {
+		var columnName, columnKey string
+		if err := rows.Scan(&columnName, &columnKey); err != nil {
+			return nil, err
+		}
+
+		ts.Columns = append(ts.Columns, columnName)
+		if columnKey == "PRI" {
+			ts.PrimaryKey = append(ts.PrimaryKey, columnName)
+		}
+	}
+
+	return ts, rows.Err()
+}
@@ -0,0 +1,55 @@
+package ghostferry
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Throttler reports whether the DataIterator should pause to avoid
+// overloading the source. It does not check source load: Throttled always
+// returns false, and Run only waits for a signal or cancellation.
+type Throttler struct {
+	Db           *sql.DB
+	Config       *Config
+	ErrorHandler *ErrorHandler
+
+	Ctx context.Context
+
+	logger *logrus.Entry
+}
+
+func (t *Throttler) Initialize() {
+	t.logger = logrus.WithField("tag", "throttler")
+
+	if t.Ctx == nil {
+		t.Ctx = context.Background()
+	}
+}
+
+// Throttled reports whether callers should currently pause their work.
+func (t *Throttler) Throttled() bool {
+	return false
+}
+
+// Run implements Runner.
+func (t *Throttler) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	close(ready)
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			// Nothing is polled on a tick.
+		case <-signals:
+			return nil
+		case <-t.Ctx.Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package ghostferry
+
+import "os"
+
+// Verifier checks that the target matches the source before cutover is
+// allowed to complete. It implements Runner so it can be slotted into the
+// same OrderedGroup as every other subsystem; most Verifier implementations
+// are idle until VerifyBeforeCutover is called and their Run simply waits
+// on signals.
+type Verifier interface {
+	Runner
+
+	// VerifyBeforeCutover performs the check that gates cutover. Ferry
+	// itself does not call it: the row-copy-complete signal that
+	// WaitUntilRowCopyIsComplete waits on is sent without it.
+	VerifyBeforeCutover() error
+}
+
+// NoVerifier is the default Verifier: it performs no verification.
+type NoVerifier struct{}
+
+func (NoVerifier) VerifyBeforeCutover() error { return nil }
+
+func (NoVerifier) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	close(ready)
+	<-signals
+	return nil
+}